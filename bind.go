@@ -0,0 +1,122 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationType is compared against by reflect.Type so time.Duration fields, which are
+// themselves an int64 kind, are parsed as durations rather than plain integers.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind populates the exported fields of the struct pointed to by dst from the loaded
+// straps. Each field is looked up by its `strap:"KeyName"` tag, falling back to the
+// field name when the tag is absent. Nested structs are bound recursively. Bind
+// supports string, bool, int, int64, float64, time.Duration and []string
+// (comma-separated) fields; a strap with no matching loaded value is left untouched.
+// Conversion failures are aggregated across every field and returned together rather
+// than stopping at the first one.
+func Bind(dst interface{}) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Bind Requires A Non-Nil Pointer To A Struct")
+	}
+
+	var errs []string
+	bindStruct(value.Elem(), &errs)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Bind Failed : %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// bindStruct walks the fields of value, assigning straps to each one and recording any
+// failure in errs.
+func bindStruct(value reflect.Value, errs *[]string) {
+	t := value.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := value.Field(i)
+
+		if field.PkgPath != "" || !fieldValue.CanSet() {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Struct && fieldValue.Type() != durationType {
+			bindStruct(fieldValue, errs)
+			continue
+		}
+
+		key := field.Tag.Get("strap")
+		if key == "" {
+			key = field.Name
+		}
+
+		if !Exists(key) {
+			continue
+		}
+
+		if err := bindField(fieldValue, Strap(key)); err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s : %s", key, err))
+		}
+	}
+}
+
+// bindField converts strap and assigns it to fieldValue according to its kind.
+func bindField(fieldValue reflect.Value, strap string) error {
+	switch {
+	case fieldValue.Type() == durationType:
+		duration, err := time.ParseDuration(strap)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(int64(duration))
+
+	case fieldValue.Kind() == reflect.String:
+		fieldValue.SetString(strap)
+
+	case fieldValue.Kind() == reflect.Bool:
+		boolean, err := strconv.ParseBool(strap)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetBool(boolean)
+
+	case fieldValue.Kind() == reflect.Int || fieldValue.Kind() == reflect.Int64:
+		integer, err := strconv.ParseInt(strap, 10, 64)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(integer)
+
+	case fieldValue.Kind() == reflect.Float64:
+		float, err := strconv.ParseFloat(strap, 64)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetFloat(float)
+
+	case fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() == reflect.String:
+		parts := strings.Split(strap, ",")
+		items := make([]string, len(parts))
+		for i, part := range parts {
+			items[i] = strings.TrimSpace(part)
+		}
+		fieldValue.Set(reflect.ValueOf(items))
+
+	default:
+		return fmt.Errorf("Unsupported Field Type %s", fieldValue.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const bindTestEnvVar = "STRAPS_TEST_BIND_ENV"
+
+// TestBindPopulatesTaggedFallbackNestedAndTypedFields covers a `strap` tag, the
+// fallback to the field name when no tag is present, recursion into a nested struct,
+// and every supported scalar/slice type.
+func TestBindPopulatesTaggedFallbackNestedAndTypedFields(t *testing.T) {
+	os.Setenv(bindTestEnvVar, "dev")
+	defer os.Unsetenv(bindTestEnvVar)
+
+	document := `{"dev": {
+		"CompanyName": "NEWCO",
+		"Debug": "true",
+		"Port": "8080",
+		"Timeout": "5s",
+		"Tags": "a, b, c",
+		"DbHost": "db.internal",
+		"DbPort": "5432"
+	}}`
+
+	if err := LoadReader(strings.NewReader(document), bindTestEnvVar, FormatJSON); err != nil {
+		t.Fatalf("LoadReader failed: %v", err)
+	}
+
+	type database struct {
+		Host string `strap:"DbHost"`
+		Port int    `strap:"DbPort"`
+	}
+
+	type config struct {
+		Name     string `strap:"CompanyName"`
+		Debug    bool   // falls back to the field name "Debug"
+		Port     int    `strap:"Port"`
+		Timeout  time.Duration `strap:"Timeout"`
+		Tags     []string      `strap:"Tags"`
+		Database database
+	}
+
+	var cfg config
+	if err := Bind(&cfg); err != nil {
+		t.Fatalf("Bind returned error: %v", err)
+	}
+
+	if cfg.Name != "NEWCO" {
+		t.Errorf("Name = %q, want %q", cfg.Name, "NEWCO")
+	}
+	if !cfg.Debug {
+		t.Error("Debug = false, want true")
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("Port = %d, want %d", cfg.Port, 8080)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want %v", cfg.Timeout, 5*time.Second)
+	}
+	if want := []string{"a", "b", "c"}; !equalStringSlices(cfg.Tags, want) {
+		t.Errorf("Tags = %v, want %v", cfg.Tags, want)
+	}
+	if cfg.Database.Host != "db.internal" {
+		t.Errorf("Database.Host = %q, want %q", cfg.Database.Host, "db.internal")
+	}
+	if cfg.Database.Port != 5432 {
+		t.Errorf("Database.Port = %d, want %d", cfg.Database.Port, 5432)
+	}
+}
+
+// TestBindAggregatesErrorsPerField checks that a conversion failure on one field
+// doesn't stop Bind from reporting failures on the others too.
+func TestBindAggregatesErrorsPerField(t *testing.T) {
+	os.Setenv(bindTestEnvVar, "dev")
+	defer os.Unsetenv(bindTestEnvVar)
+
+	document := `{"dev": {"BadPort": "not-a-number", "BadRatio": "not-a-float"}}`
+	if err := LoadReader(strings.NewReader(document), bindTestEnvVar, FormatJSON); err != nil {
+		t.Fatalf("LoadReader failed: %v", err)
+	}
+
+	type config struct {
+		Port  int     `strap:"BadPort"`
+		Ratio float64 `strap:"BadRatio"`
+	}
+
+	var cfg config
+	err := Bind(&cfg)
+	if err == nil {
+		t.Fatal("expected Bind to report an error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "BadPort") || !strings.Contains(err.Error(), "BadRatio") {
+		t.Errorf("Bind error %q does not mention both failing fields", err.Error())
+	}
+}
+
+// TestBindRequiresPointerToStruct checks the guard against a misuse that would
+// otherwise panic deep inside the reflect package.
+func TestBindRequiresPointerToStruct(t *testing.T) {
+	type config struct {
+		Name string `strap:"CompanyName"`
+	}
+
+	if err := Bind(config{}); err == nil {
+		t.Error("expected Bind(config{}) (non-pointer) to return an error, got nil")
+	}
+
+	if err := Bind((*config)(nil)); err == nil {
+		t.Error("expected Bind((*config)(nil)) to return an error, got nil")
+	}
+}
+
+// TestBindLeavesFieldUntouchedWhenStrapMissing checks Bind doesn't zero out fields for
+// keys that were never loaded.
+func TestBindLeavesFieldUntouchedWhenStrapMissing(t *testing.T) {
+	os.Setenv(bindTestEnvVar, "dev")
+	defer os.Unsetenv(bindTestEnvVar)
+
+	document := `{"dev": {"CompanyName": "NEWCO"}}`
+	if err := LoadReader(strings.NewReader(document), bindTestEnvVar, FormatJSON); err != nil {
+		t.Fatalf("LoadReader failed: %v", err)
+	}
+
+	type config struct {
+		Name string `strap:"CompanyName"`
+		Port int     `strap:"Port"`
+	}
+
+	cfg := config{Port: 9090}
+	if err := Bind(&cfg); err != nil {
+		t.Fatalf("Bind returned error: %v", err)
+	}
+
+	if cfg.Port != 9090 {
+		t.Errorf("Port = %d, want the untouched default %d", cfg.Port, 9090)
+	}
+}
+
+// equalStringSlices reports whether a and b contain the same strings in the same order.
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,83 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"os"
+	"regexp"
+)
+
+// Options controls optional behavior when loading straps.
+type Options struct {
+	// ExpandEnv, when true, recursively expands ${VAR}, ${VAR:-default}/${VAR|default}
+	// and bare $VAR references in every loaded strap value against the process
+	// environment.
+	ExpandEnv bool
+}
+
+// maxExpandDepth caps recursive expansion so a value that references itself, directly
+// or through a chain of environment variables, cannot loop forever.
+const maxExpandDepth = 10
+
+// envReferencePattern matches ${NAME}, ${NAME:-default} and ${NAME|default} tokens.
+var envReferencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|\|)([^}]*))?\}`)
+
+// bareEnvPattern matches a value that is entirely a $NAME reference, yielding the
+// whole environment variable rather than substituting within a larger string.
+var bareEnvPattern = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// LoadWithOptions retrieves application configuration the same way as MustLoad, then
+// applies the behavior requested by options. options is remembered so a Watch-triggered
+// reload of the underlying file can reapply it to the freshly decoded values.
+func LoadWithOptions(environmentVariable string, relativeGoPathLocation string, options Options) {
+	MustLoad(environmentVariable, relativeGoPathLocation)
+
+	strapsMu.Lock()
+	defer strapsMu.Unlock()
+
+	loadedOptions = options
+
+	if options.ExpandEnv {
+		for key, value := range st.strapMap {
+			st.strapMap[key] = expandEnv(value, 0)
+		}
+	}
+}
+
+// StrapExpand returns the value for the specified key with environment variable
+// references expanded; see LoadWithOptions for the supported token forms.
+func StrapExpand(key string) string {
+	return expandEnv(Strap(key), 0)
+}
+
+// expandEnv substitutes ${NAME}, ${NAME:-default}/${NAME|default} and bare $NAME
+// references in value from the process environment, repeating until the value stops
+// changing or depth reaches maxExpandDepth.
+func expandEnv(value string, depth int) string {
+	if depth >= maxExpandDepth {
+		return value
+	}
+
+	if match := bareEnvPattern.FindStringSubmatch(value); match != nil {
+		return expandEnv(os.Getenv(match[1]), depth+1)
+	}
+
+	expanded := envReferencePattern.ReplaceAllStringFunc(value, func(token string) string {
+		parts := envReferencePattern.FindStringSubmatch(token)
+		name, defaultValue := parts[1], parts[3]
+
+		if envValue, found := os.LookupEnv(name); found {
+			return envValue
+		}
+
+		return defaultValue
+	})
+
+	if expanded == value {
+		return expanded
+	}
+
+	return expandEnv(expanded, depth+1)
+}
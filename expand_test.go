@@ -0,0 +1,65 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestExpandEnv covers the token forms expandEnv is documented to support: ${NAME},
+// ${NAME:-default}, ${NAME|default}, a bare $NAME, and a value that embeds several of
+// them.
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("STRAPS_TEST_HOST", "db.internal")
+	defer os.Unsetenv("STRAPS_TEST_HOST")
+	os.Unsetenv("STRAPS_TEST_MISSING")
+
+	cases := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"set var", "${STRAPS_TEST_HOST}", "db.internal"},
+		{"colon default used", "${STRAPS_TEST_MISSING:-app}", "app"},
+		{"pipe default used", "${STRAPS_TEST_MISSING|app}", "app"},
+		{"set var wins over default", "${STRAPS_TEST_HOST:-fallback}", "db.internal"},
+		{"bare form", "$STRAPS_TEST_HOST", "db.internal"},
+		{
+			"embedded references",
+			"postgres://${STRAPS_TEST_MISSING:-app}@${STRAPS_TEST_HOST}/app",
+			"postgres://app@db.internal/app",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := expandEnv(c.value, 0); got != c.want {
+				t.Errorf("expandEnv(%q) = %q, want %q", c.value, got, c.want)
+			}
+		})
+	}
+}
+
+// TestExpandEnvCycleIsBounded ensures a strap that references itself, directly or
+// through another env var, cannot send expandEnv into an infinite loop.
+func TestExpandEnvCycleIsBounded(t *testing.T) {
+	os.Setenv("STRAPS_TEST_CYCLE_A", "${STRAPS_TEST_CYCLE_B}")
+	os.Setenv("STRAPS_TEST_CYCLE_B", "${STRAPS_TEST_CYCLE_A}")
+	defer os.Unsetenv("STRAPS_TEST_CYCLE_A")
+	defer os.Unsetenv("STRAPS_TEST_CYCLE_B")
+
+	done := make(chan string, 1)
+	go func() {
+		done <- expandEnv("${STRAPS_TEST_CYCLE_A}", 0)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expandEnv did not terminate on a cyclic reference")
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Format identifies the file encoding of a straps configuration document. The original
+// straps.xml layout is one of several supported backends.
+type Format int
+
+// The set of Format values straps knows how to decode.
+const (
+	FormatXML Format = iota
+	FormatJSON
+	FormatYAML
+	FormatTOML
+	FormatINI
+)
+
+// formatExtensions maps a recognized file extension to the Format used to decode it.
+var formatExtensions = map[string]Format{
+	".xml":  FormatXML,
+	".json": FormatJSON,
+	".yaml": FormatYAML,
+	".yml":  FormatYAML,
+	".toml": FormatTOML,
+	".ini":  FormatINI,
+}
+
+// formatDecoder decodes a straps document and returns the key/value pairs for the
+// environment named by environmentVariable.
+type formatDecoder interface {
+	Decode(reader io.Reader, environmentVariable string) (map[string]string, error)
+}
+
+// formatDecoders holds the decoder implementation for every supported Format.
+var formatDecoders = map[Format]formatDecoder{
+	FormatXML:  xmlFormatDecoder{},
+	FormatJSON: jsonFormatDecoder{},
+	FormatYAML: yamlFormatDecoder{},
+	FormatTOML: tomlFormatDecoder{},
+	FormatINI:  iniFormatDecoder{},
+}
+
+// decodeFormat resolves the decoder for format and uses it to read reader.
+func decodeFormat(format Format, reader io.Reader, environmentVariable string) (map[string]string, error) {
+	decoder, found := formatDecoders[format]
+	if !found {
+		return nil, fmt.Errorf("Unsupported Strap Format [%d]", format)
+	}
+
+	return decoder.Decode(reader, environmentVariable)
+}
+
+// LoadFormat retrieves application configuration from the file at path, decoded
+// according to format. It shares its decode/lock/swap logic with Load and LoadFile via
+// loadFrom, so a file loaded this way can also be watched with Watch.
+func LoadFormat(path string, environmentVariable string, format Format) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return loadFrom(file, path, environmentVariable, format)
+}
+
+// LoadReader retrieves application configuration from reader, decoded according to
+// format. Because reader has no associated file path, the load it produces cannot be
+// watched with Watch, and StrapSource reports "reader" for every key it sets that no
+// STRAP_<KEY> process override replaces.
+func LoadReader(reader io.Reader, environmentVariable string, format Format) error {
+	strapMap, err := decodeFormat(format, reader, environmentVariable)
+	if err != nil {
+		return err
+	}
+
+	strapsMu.Lock()
+	defer strapsMu.Unlock()
+
+	st = straps{strapMap: strapMap}
+	loadedPath = ""
+	loadedEnvironmentVariable = environmentVariable
+	loadedFormat = format
+	loadedOptions = Options{}
+	overlayHistory = nil
+
+	strapSources = make(map[string]string, len(strapMap))
+	for key := range strapMap {
+		strapSources[key] = "reader"
+	}
+
+	applyProcessOverridesLocked(strapMap)
+
+	return nil
+}
+
+// resolveEnvironmentName looks up the environment name selected by environmentVariable.
+func resolveEnvironmentName(environmentVariable string) (string, error) {
+	var environment string
+	if len(environmentVariable) > 0 {
+		environment = os.Getenv(environmentVariable)
+	}
+
+	if len(environment) == 0 {
+		return "", fmt.Errorf("Environment Variable [%s] Does Not Exist", environmentVariable)
+	}
+
+	return environment, nil
+}
+
+// selectEnvironment picks the strap map for the environment named by environmentVariable
+// out of a document keyed by environment name.
+func selectEnvironment(document map[string]map[string]string, environmentVariable string) (map[string]string, error) {
+	environment, err := resolveEnvironmentName(environmentVariable)
+	if err != nil {
+		return nil, err
+	}
+
+	strapMap, found := document[environment]
+	if !found {
+		return nil, fmt.Errorf("No Environment With Name %s Found", environment)
+	}
+
+	return strapMap, nil
+}
+
+// xmlFormatDecoder decodes the original straps.xml layout.
+type xmlFormatDecoder struct{}
+
+// Decode implements formatDecoder for xmlFormatDecoder.
+func (xmlFormatDecoder) Decode(reader io.Reader, environmentVariable string) (map[string]string, error) {
+	var document xmlStraps
+	if err := xml.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, fmt.Errorf("Unable To Read Straps File : %s", err)
+	}
+
+	environment, err := resolveEnvironmentName(environmentVariable)
+	if err != nil {
+		return nil, err
+	}
+
+	var useEnv *xmlEnv
+	for i := range document.Environments {
+		if document.Environments[i].Name == environment {
+			useEnv = &document.Environments[i]
+			break
+		}
+	}
+
+	if useEnv == nil {
+		return nil, fmt.Errorf("No Environment With Name %s Found", environment)
+	}
+
+	strapMap := make(map[string]string)
+	for _, strap := range useEnv.Straps {
+		strapMap[strap.Key] = strap.Value
+	}
+
+	return strapMap, nil
+}
+
+// jsonFormatDecoder decodes a straps document shaped as a JSON object keyed by
+// environment name, e.g. {"dev": {"CompanyName": "NEWCO-DEV"}}.
+type jsonFormatDecoder struct{}
+
+// Decode implements formatDecoder for jsonFormatDecoder.
+func (jsonFormatDecoder) Decode(reader io.Reader, environmentVariable string) (map[string]string, error) {
+	var document map[string]map[string]string
+	if err := json.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, fmt.Errorf("Unable To Read Straps File : %s", err)
+	}
+
+	return selectEnvironment(document, environmentVariable)
+}
+
+// yamlFormatDecoder decodes a flat YAML subset: top level keys name an environment and
+// their indented children are its straps. Nested mappings, lists and anchors are not
+// supported, matching the flat shape straps.xml already uses.
+type yamlFormatDecoder struct{}
+
+// Decode implements formatDecoder for yamlFormatDecoder.
+func (yamlFormatDecoder) Decode(reader io.Reader, environmentVariable string) (map[string]string, error) {
+	document, err := parseIndentedKeyValue(reader)
+	if err != nil {
+		return nil, fmt.Errorf("Unable To Read Straps File : %s", err)
+	}
+
+	return selectEnvironment(document, environmentVariable)
+}
+
+// parseIndentedKeyValue parses the YAML subset described on yamlFormatDecoder.
+func parseIndentedKeyValue(reader io.Reader) (map[string]map[string]string, error) {
+	document := make(map[string]map[string]string)
+
+	var section string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			section = strings.TrimSuffix(trimmed, ":")
+			document[section] = make(map[string]string)
+			continue
+		}
+
+		if section == "" {
+			return nil, fmt.Errorf("Strap [%s] Found Before Any Environment Section", trimmed)
+		}
+
+		key, value, err := splitKeyValue(trimmed, ":")
+		if err != nil {
+			return nil, err
+		}
+
+		document[section][key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// tomlFormatDecoder decodes a flat TOML subset: a [section] per environment holding
+// key = value straps. Tables, arrays and inline tables are not supported, matching the
+// flat shape straps.xml already uses.
+type tomlFormatDecoder struct{}
+
+// Decode implements formatDecoder for tomlFormatDecoder.
+func (tomlFormatDecoder) Decode(reader io.Reader, environmentVariable string) (map[string]string, error) {
+	document, err := parseSectionedKeyValue(reader)
+	if err != nil {
+		return nil, fmt.Errorf("Unable To Read Straps File : %s", err)
+	}
+
+	return selectEnvironment(document, environmentVariable)
+}
+
+// iniFormatDecoder decodes a standard INI document: a [section] per environment holding
+// key = value straps.
+type iniFormatDecoder struct{}
+
+// Decode implements formatDecoder for iniFormatDecoder.
+func (iniFormatDecoder) Decode(reader io.Reader, environmentVariable string) (map[string]string, error) {
+	document, err := parseSectionedKeyValue(reader)
+	if err != nil {
+		return nil, fmt.Errorf("Unable To Read Straps File : %s", err)
+	}
+
+	return selectEnvironment(document, environmentVariable)
+}
+
+// parseSectionedKeyValue parses the [section] / key = value shape shared by the TOML
+// and INI decoders.
+func parseSectionedKeyValue(reader io.Reader) (map[string]map[string]string, error) {
+	document := make(map[string]map[string]string)
+
+	var section string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			document[section] = make(map[string]string)
+			continue
+		}
+
+		if section == "" {
+			return nil, fmt.Errorf("Strap [%s] Found Before Any Environment Section", line)
+		}
+
+		key, value, err := splitKeyValue(line, "=")
+		if err != nil {
+			return nil, err
+		}
+
+		document[section][key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// splitKeyValue splits a "key<separator>value" line, trimming whitespace and any
+// surrounding quotes from the value.
+func splitKeyValue(line string, separator string) (string, string, error) {
+	index := strings.Index(line, separator)
+	if index == -1 {
+		return "", "", fmt.Errorf("Malformed Strap Line [%s]", line)
+	}
+
+	key := strings.TrimSpace(line[:index])
+	value := strings.TrimSpace(line[index+1:])
+	value = strings.Trim(value, `"'`)
+
+	return key, value, nil
+}
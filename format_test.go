@@ -0,0 +1,110 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const formatTestEnvVar = "STRAPS_TEST_FORMAT_ENV"
+
+// TestDecodeFormats exercises every format backend against an equivalent document,
+// asserting they all resolve the same strap the same way.
+func TestDecodeFormats(t *testing.T) {
+	os.Setenv(formatTestEnvVar, "dev")
+	defer os.Unsetenv(formatTestEnvVar)
+
+	cases := []struct {
+		name     string
+		format   Format
+		document string
+	}{
+		{
+			name:     "xml",
+			format:   FormatXML,
+			document: `<straps><env name="dev"><strap key="CompanyName" value="NEWCO-DEV"/></env></straps>`,
+		},
+		{
+			name:     "json",
+			format:   FormatJSON,
+			document: `{"dev": {"CompanyName": "NEWCO-DEV"}}`,
+		},
+		{
+			name:     "yaml",
+			format:   FormatYAML,
+			document: "dev:\n  CompanyName: NEWCO-DEV\n",
+		},
+		{
+			name:     "toml",
+			format:   FormatTOML,
+			document: "[dev]\nCompanyName = \"NEWCO-DEV\"\n",
+		},
+		{
+			name:     "ini",
+			format:   FormatINI,
+			document: "[dev]\nCompanyName=NEWCO-DEV\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := LoadReader(strings.NewReader(c.document), formatTestEnvVar, c.format); err != nil {
+				t.Fatalf("LoadReader returned error: %v", err)
+			}
+
+			if got := Strap("CompanyName"); got != "NEWCO-DEV" {
+				t.Errorf("Strap(CompanyName) = %q, want %q", got, "NEWCO-DEV")
+			}
+		})
+	}
+}
+
+// TestDecodeFormatsMissingEnvironment checks every backend reports a clear error
+// instead of silently loading nothing when the selected environment is absent.
+func TestDecodeFormatsMissingEnvironment(t *testing.T) {
+	os.Setenv(formatTestEnvVar, "staging")
+	defer os.Unsetenv(formatTestEnvVar)
+
+	cases := []struct {
+		name     string
+		format   Format
+		document string
+	}{
+		{"xml", FormatXML, `<straps><env name="dev"><strap key="A" value="1"/></env></straps>`},
+		{"json", FormatJSON, `{"dev": {"A": "1"}}`},
+		{"yaml", FormatYAML, "dev:\n  A: 1\n"},
+		{"toml", FormatTOML, "[dev]\nA = \"1\"\n"},
+		{"ini", FormatINI, "[dev]\nA=1\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := LoadReader(strings.NewReader(c.document), formatTestEnvVar, c.format)
+			if err == nil {
+				t.Fatal("expected an error for a missing environment, got nil")
+			}
+		})
+	}
+}
+
+// TestParseSectionedKeyValueRejectsKeyBeforeSection covers the malformed-input path
+// shared by the TOML and INI decoders.
+func TestParseSectionedKeyValueRejectsKeyBeforeSection(t *testing.T) {
+	_, err := parseSectionedKeyValue(strings.NewReader("A=1\n[dev]\nB=2\n"))
+	if err == nil {
+		t.Fatal("expected an error for a key found before any section, got nil")
+	}
+}
+
+// TestParseIndentedKeyValueRejectsKeyBeforeSection covers the malformed-input path for
+// the YAML decoder.
+func TestParseIndentedKeyValueRejectsKeyBeforeSection(t *testing.T) {
+	_, err := parseIndentedKeyValue(strings.NewReader("  A: 1\ndev:\n  B: 2\n"))
+	if err == nil {
+		t.Fatal("expected an error for a key found before any section, got nil")
+	}
+}
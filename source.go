@@ -0,0 +1,159 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// strapSources records, for each loaded key, the name of the source that last supplied
+// its value, so operators can debug where a value came from.
+var strapSources = make(map[string]string)
+
+// overlayRecord captures one application of Merge or LoadDotEnv, so a later reload of
+// the base file can replay it on top of the freshly decoded values.
+type overlayRecord struct {
+	name     string
+	strapMap map[string]string
+}
+
+// overlayHistory records every overlay applied via Merge or LoadDotEnv, in application
+// order. It is replayed by reloadStrapsFile after the base file changes, and cleared
+// whenever a new base file is loaded.
+var overlayHistory []overlayRecord
+
+// Merge decodes reader as format and applies its straps on top of whatever is already
+// loaded: later sources override earlier ones for any key they both define. Process
+// environment variables of the form STRAP_<KEY> are re-applied after the merge and take
+// precedence over every file-based source.
+func Merge(reader io.Reader, format Format) error {
+	strapsMu.RLock()
+	environmentVariable := loadedEnvironmentVariable
+	strapsMu.RUnlock()
+
+	strapMap, err := decodeFormat(format, reader, environmentVariable)
+	if err != nil {
+		return err
+	}
+
+	applySource("merge", strapMap)
+	applyProcessOverrides()
+
+	return nil
+}
+
+// LoadDotEnv layers one or more dotenv files (KEY=VALUE per line) on top of whatever is
+// already loaded, in the order given, with later files overriding earlier ones.
+// Process environment variables of the form STRAP_<KEY> take precedence over every
+// file-based source, including these.
+func LoadDotEnv(paths ...string) error {
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		strapMap, err := parseDotEnv(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		applySource(path, strapMap)
+	}
+
+	applyProcessOverrides()
+
+	return nil
+}
+
+// parseDotEnv parses the simple KEY=VALUE shape used by dotenv files.
+func parseDotEnv(reader io.Reader) (map[string]string, error) {
+	strapMap := make(map[string]string)
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, err := splitKeyValue(line, "=")
+		if err != nil {
+			return nil, err
+		}
+
+		strapMap[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return strapMap, nil
+}
+
+// applySource copies every key in strapMap into st, recording name as each key's
+// source, and records the overlay so a later base-file reload can replay it.
+func applySource(name string, strapMap map[string]string) {
+	strapsMu.Lock()
+	defer strapsMu.Unlock()
+
+	if st.strapMap == nil {
+		st = straps{strapMap: make(map[string]string)}
+	}
+
+	for key, value := range strapMap {
+		st.strapMap[key] = value
+		strapSources[key] = name
+	}
+
+	overlayHistory = append(overlayHistory, overlayRecord{name: name, strapMap: strapMap})
+}
+
+// applyOverlaysLocked replays every overlay recorded in overlayHistory onto strapMap, in
+// the order they were originally applied. The caller must hold strapsMu.
+func applyOverlaysLocked(strapMap map[string]string) {
+	for _, overlay := range overlayHistory {
+		for key, value := range overlay.strapMap {
+			strapMap[key] = value
+			strapSources[key] = overlay.name
+		}
+	}
+}
+
+// applyProcessOverrides gives STRAP_<KEY> process environment variables the final say
+// over every key they name, regardless of which source last set it.
+func applyProcessOverrides() {
+	strapsMu.Lock()
+	defer strapsMu.Unlock()
+
+	applyProcessOverridesLocked(st.strapMap)
+}
+
+// applyProcessOverridesLocked applies STRAP_<KEY> overrides to strapMap in place. The
+// caller must hold strapsMu.
+func applyProcessOverridesLocked(strapMap map[string]string) {
+	for key := range strapMap {
+		if value, found := os.LookupEnv("STRAP_" + key); found {
+			strapMap[key] = value
+			strapSources[key] = "environment"
+		}
+	}
+}
+
+// StrapSource returns the name of the source that supplied key's current value: the
+// file path it was loaded from (a straps file or a dotenv file), "merge" for a value
+// applied by Merge, "reader" for one loaded by LoadReader, "environment" for a
+// STRAP_<KEY> override, or "" if key has not been loaded from any tracked source.
+func StrapSource(key string) string {
+	strapsMu.RLock()
+	defer strapsMu.RUnlock()
+
+	return strapSources[key]
+}
@@ -0,0 +1,151 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMergeOverridesEarlierSourceAndTracksSource checks that a later Merge call wins
+// over the base file for a shared key, leaves an untouched key alone, and that
+// StrapSource reports the right origin for each.
+func TestMergeOverridesEarlierSourceAndTracksSource(t *testing.T) {
+	const envVar = "STRAPS_TEST_MERGE_ENV"
+	os.Setenv(envVar, "dev")
+	defer os.Unsetenv(envVar)
+
+	path := filepath.Join(t.TempDir(), "straps.xml")
+	initial := `<straps><env name="dev"><strap key="Foo" value="base-value"/><strap key="Bar" value="bar-value"/></env></straps>`
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadFormat(path, envVar, FormatXML); err != nil {
+		t.Fatalf("LoadFormat failed: %v", err)
+	}
+
+	if got := StrapSource("Foo"); got != path {
+		t.Errorf("StrapSource(Foo) = %q before merge, want %q", got, path)
+	}
+
+	if err := Merge(strings.NewReader(`{"dev": {"Foo": "merged-value"}}`), FormatJSON); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if got := Strap("Foo"); got != "merged-value" {
+		t.Errorf("Strap(Foo) = %q, want %q", got, "merged-value")
+	}
+	if got := StrapSource("Foo"); got != "merge" {
+		t.Errorf("StrapSource(Foo) = %q, want %q", got, "merge")
+	}
+
+	if got := Strap("Bar"); got != "bar-value" {
+		t.Errorf("Strap(Bar) = %q, want the untouched %q", got, "bar-value")
+	}
+	if got := StrapSource("Bar"); got != path {
+		t.Errorf("StrapSource(Bar) = %q, want the original file path %q", got, path)
+	}
+}
+
+// TestLoadDotEnvAppliesFilesInOrder checks that later dotenv files override earlier
+// ones for keys they both define, while still layering in their own distinct keys.
+func TestLoadDotEnvAppliesFilesInOrder(t *testing.T) {
+	const envVar = "STRAPS_TEST_DOTENV_ORDER_ENV"
+	os.Setenv(envVar, "dev")
+	defer os.Unsetenv(envVar)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "straps.xml")
+	if err := os.WriteFile(path, []byte(`<straps><env name="dev"><strap key="Base" value="base-value"/></env></straps>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadFormat(path, envVar, FormatXML); err != nil {
+		t.Fatalf("LoadFormat failed: %v", err)
+	}
+
+	firstPath := filepath.Join(dir, "first.env")
+	if err := os.WriteFile(firstPath, []byte("Shared=first\nOnlyFirst=only-first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	secondPath := filepath.Join(dir, "second.env")
+	if err := os.WriteFile(secondPath, []byte("Shared=second\nOnlySecond=only-second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadDotEnv(firstPath, secondPath); err != nil {
+		t.Fatalf("LoadDotEnv failed: %v", err)
+	}
+
+	if got := Strap("Shared"); got != "second" {
+		t.Errorf("Strap(Shared) = %q, want the later file's %q to win", got, "second")
+	}
+	if got := Strap("OnlyFirst"); got != "only-first" {
+		t.Errorf("Strap(OnlyFirst) = %q, want %q", got, "only-first")
+	}
+	if got := Strap("OnlySecond"); got != "only-second" {
+		t.Errorf("Strap(OnlySecond) = %q, want %q", got, "only-second")
+	}
+	if got := Strap("Base"); got != "base-value" {
+		t.Errorf("Strap(Base) = %q, want the untouched %q", got, "base-value")
+	}
+}
+
+// TestProcessOverrideTakesPrecedenceOverMergeAndDotEnv checks that a STRAP_<KEY>
+// process environment variable always wins, regardless of which file-based source
+// last set the key, and that it is applied immediately by both Merge and LoadDotEnv.
+func TestProcessOverrideTakesPrecedenceOverMergeAndDotEnv(t *testing.T) {
+	const envVar = "STRAPS_TEST_OVERRIDE_PRECEDENCE_ENV"
+	os.Setenv(envVar, "dev")
+	defer os.Unsetenv(envVar)
+
+	path := filepath.Join(t.TempDir(), "straps.xml")
+	if err := os.WriteFile(path, []byte(`<straps><env name="dev"><strap key="Foo" value="base-value"/></env></straps>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadFormat(path, envVar, FormatXML); err != nil {
+		t.Fatalf("LoadFormat failed: %v", err)
+	}
+
+	os.Setenv("STRAP_Foo", "override-value")
+	defer os.Unsetenv("STRAP_Foo")
+
+	if err := Merge(strings.NewReader(`{"dev": {"Foo": "merged-value"}}`), FormatJSON); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if got := Strap("Foo"); got != "override-value" {
+		t.Errorf("Strap(Foo) = %q after Merge, want the process override %q to win", got, "override-value")
+	}
+	if got := StrapSource("Foo"); got != "environment" {
+		t.Errorf("StrapSource(Foo) = %q, want %q", got, "environment")
+	}
+
+	dotEnvPath := filepath.Join(filepath.Dir(path), ".env")
+	if err := os.WriteFile(dotEnvPath, []byte("Foo=dotenv-value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadDotEnv(dotEnvPath); err != nil {
+		t.Fatalf("LoadDotEnv failed: %v", err)
+	}
+
+	if got := Strap("Foo"); got != "override-value" {
+		t.Errorf("Strap(Foo) = %q after LoadDotEnv, want the process override %q to still win", got, "override-value")
+	}
+}
+
+// TestStrapSourceUnknownKeyReturnsEmpty checks the documented zero-value behavior for a
+// key that was never loaded from any source.
+func TestStrapSourceUnknownKeyReturnsEmpty(t *testing.T) {
+	if got := StrapSource("NoSuchStrapsKeyEverLoaded"); got != "" {
+		t.Errorf("StrapSource(unknown) = %q, want %q", got, "")
+	}
+}
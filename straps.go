@@ -9,7 +9,32 @@ variable in an XML document.
 Configuration
 
 Straps expects a file name straps.xml to be located in the default working directory or
-in the GOPATH plus a provided relative path location.
+in the GOPATH plus a provided relative path location. MustLoad also recognizes
+straps.json, straps.yaml, straps.yml, straps.toml and straps.ini by extension; use
+LoadFormat or LoadReader to decode a specific Format from a path or reader of your own
+choosing.
+
+Strap values may reference process environment variables with ${NAME}, ${NAME:-default},
+${NAME|default} or a bare $NAME. Expansion is opt-in: call LoadWithOptions with
+Options{ExpandEnv: true} to expand every strap at load time, or call StrapExpand(key) to
+expand a single value on demand.
+
+Once loaded from a file, call Watch to receive an Event on every key whose value changes
+when that file is modified, or register OnChange(key, callback) for a specific key. All
+accessors are safe to call concurrently with a Watch reload.
+
+Call Bind to populate a struct from the loaded straps instead of reading keys one at a
+time; fields are matched by a `strap:"KeyName"` tag, falling back to the field name.
+
+Call LoadDotEnv or Merge to layer additional sources on top of whatever is already
+loaded; later sources win, and a process environment variable named STRAP_<KEY> always
+wins over every file-based source. StrapSource(key) reports where a value came from.
+
+MustLoad panics on failure, matching the _CatchPanic pattern below, while Strap,
+StrapBool and StrapInt return "", false or -1 for a missing or unparsable key. Load and
+LoadFile return an error instead, and TryStrap, TryStrapBool and TryStrapInt report a
+missing or unparsable key with a bool rather than a placeholder value, for callers that
+would rather not panic at all.
 
 	<straps>
 	  <env name="dev">
@@ -59,16 +84,22 @@ package straps
 import (
 	"encoding/xml"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var (
-	st straps // A reference to the singleton.
+	st       straps       // A reference to the singleton.
+	strapsMu sync.RWMutex // Guards st for concurrent access, including Watch reloads.
+
+	loadedPath                string  // The file path st was last loaded from, if any.
+	loadedEnvironmentVariable string  // The environment variable used for the last load.
+	loadedFormat              Format  // The Format used for the last load.
+	loadedOptions             Options // The Options passed to the last LoadWithOptions call, if any.
 )
 
 type (
@@ -99,50 +130,124 @@ type (
 	}
 )
 
-// MustLoad retrieves application configuration.
+// strapsFileNames lists the straps file names MustLoad searches for, in priority order.
+// The format used to decode each one is sniffed from its extension.
+var strapsFileNames = []string{
+	"straps.xml",
+	"straps.json",
+	"straps.yaml",
+	"straps.yml",
+	"straps.toml",
+	"straps.ini",
+}
+
+// Load retrieves application configuration, returning an error instead of panicking if
+// the straps file cannot be located or decoded.
+func Load(environmentVariable string, relativeGoPathLocation string) error {
+	file, path, format, err := locateStrapsFile(relativeGoPathLocation)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return loadFrom(file, path, environmentVariable, format)
+}
+
+// MustLoad retrieves application configuration, panicking if it cannot be loaded. It is
+// a thin wrapper over Load for callers that prefer to panic rather than check an error.
 func MustLoad(environmentVariable string, relativeGoPathLocation string) {
-	// Find the location of the straps.xml file.
-	strapsFilePath, err := filepath.Abs("straps.xml")
+	if err := Load(environmentVariable, relativeGoPathLocation); err != nil {
+		panic(err.Error())
+	}
+}
 
-	// Open the straps.xml file.
-	file, err := os.Open(strapsFilePath)
+// LoadFile retrieves application configuration from the file at path, sniffing the
+// Format from its extension, and returns an error instead of panicking.
+func LoadFile(path string, environmentVariable string) error {
+	format, found := formatExtensions[filepath.Ext(path)]
+	if !found {
+		return fmt.Errorf("Unrecognized Straps File Extension [%s]", filepath.Ext(path))
+	}
+
+	file, err := os.Open(path)
 	if err != nil {
-		// Check the gopath.
-		goPath := os.Getenv("GOPATH")
+		return err
+	}
+	defer file.Close()
 
-		// Panic
-		if goPath == "" {
-			panic("Unable To Locate Straps File")
-		}
+	return loadFrom(file, path, environmentVariable, format)
+}
+
+// loadFrom decodes file with format for environmentVariable and swaps it in as the
+// active straps, recording path, environment variable and format for later use by Watch
+// (Merge reuses the environment variable). STRAP_<KEY> process overrides are applied on
+// top. It starts a new load lifecycle, so any Options and overlays (from
+// LoadWithOptions, Merge or LoadDotEnv) recorded against a previous load are cleared.
+func loadFrom(file *os.File, path string, environmentVariable string, format Format) error {
+	strapMap, err := decodeFormat(format, file, environmentVariable)
+	if err != nil {
+		return err
+	}
 
-		// Remove the ending / if it exists.
-		goPath = strings.TrimRight(goPath, "/")
+	strapsMu.Lock()
+	defer strapsMu.Unlock()
 
-		// Open the straps.xml file.
-		file, err = os.Open(fmt.Sprintf("%s/src/%s/straps.xml", goPath, relativeGoPathLocation))
+	st = straps{strapMap: strapMap}
+	loadedPath = path
+	loadedEnvironmentVariable = environmentVariable
+	loadedFormat = format
+	loadedOptions = Options{}
+	overlayHistory = nil
+
+	strapSources = make(map[string]string, len(strapMap))
+	for key := range strapMap {
+		strapSources[key] = path
+	}
+
+	applyProcessOverridesLocked(strapMap)
+
+	return nil
+}
+
+// locateStrapsFile finds the first straps file in the default working directory or,
+// failing that, the GOPATH plus the provided relative path location. The format
+// returned is sniffed from the file's extension.
+func locateStrapsFile(relativeGoPathLocation string) (*os.File, string, Format, error) {
+	for _, name := range strapsFileNames {
+		strapsFilePath, err := filepath.Abs(name)
 		if err != nil {
-			panic(err.Error())
+			continue
+		}
+
+		if file, err := os.Open(strapsFilePath); err == nil {
+			return file, strapsFilePath, formatExtensions[filepath.Ext(name)], nil
 		}
 	}
 
-	defer file.Close()
+	// Check the gopath.
+	goPath := os.Getenv("GOPATH")
+	if goPath == "" {
+		return nil, "", 0, fmt.Errorf("Unable To Locate Straps File")
+	}
 
-	// Read the straps file.
-	xmlStraps := mustReadStraps(file, environmentVariable)
+	// Remove the ending / if it exists.
+	goPath = strings.TrimRight(goPath, "/")
 
-	// Create a straps object.
-	st = straps{
-		strapMap: make(map[string]string),
+	for _, name := range strapsFileNames {
+		strapsFilePath := fmt.Sprintf("%s/src/%s/%s", goPath, relativeGoPathLocation, name)
+		if file, err := os.Open(strapsFilePath); err == nil {
+			return file, strapsFilePath, formatExtensions[filepath.Ext(name)], nil
+		}
 	}
 
-	// Store the key/value pairs for each strap
-	for _, strap := range xmlStraps {
-		st.strapMap[strap.Key] = strap.Value
-	}
+	return nil, "", 0, fmt.Errorf("Unable To Locate Straps File")
 }
 
 // Exists returns true if the key exists else false.
 func Exists(key string) (found bool) {
+	strapsMu.RLock()
+	defer strapsMu.RUnlock()
+
 	_, found = st.strapMap[key]
 	return found
 }
@@ -150,6 +255,9 @@ func Exists(key string) (found bool) {
 // Strap returns the value for the specified key
 //  key: The key lookup string defined in the straps file
 func Strap(key string) string {
+	strapsMu.RLock()
+	defer strapsMu.RUnlock()
+
 	return st.strapMap[key]
 }
 
@@ -159,6 +267,9 @@ func StrapRegexp(keyRegexp string) (matches []string) {
 
 	find := regexp.MustCompile(keyRegexp)
 
+	strapsMu.RLock()
+	defer strapsMu.RUnlock()
+
 	for key, value := range st.strapMap {
 		if find.MatchString(key) == true {
 			matches = append(matches, value)
@@ -192,38 +303,45 @@ func StrapInt(key string) int {
 	return integer
 }
 
-//** PRIVATE FUNCTIONS
+// TryStrap returns the value for the specified key and whether it was found, instead of
+// silently returning the empty string like Strap.
+func TryStrap(key string) (string, bool) {
+	strapsMu.RLock()
+	defer strapsMu.RUnlock()
 
-// mustReadStraps reads the straps.xml file and decodes the XML.
-func mustReadStraps(reader io.Reader, environmentVariable string) []xmlStrap {
-	var xmlStraps xmlStraps
-	if err := xml.NewDecoder(reader).Decode(&xmlStraps); err != nil {
-		panic("Unable To Read Staps File")
-	}
+	value, found := st.strapMap[key]
+	return value, found
+}
 
-	// Identify the enviornment to use.
-	var environment string
-	if len(environmentVariable) > 0 {
-		environment = os.Getenv(environmentVariable)
+// TryStrapBool returns the value for the specified key as a bool, and whether it was
+// found and successfully parsed.
+func TryStrapBool(key string) (bool, bool) {
+	strap, found := TryStrap(key)
+	if !found {
+		return false, false
 	}
 
-	// If the environment variable does not exist, panic.
-	if len(environment) == 0 {
-		panic(fmt.Errorf("Environment Variable [%s] Does Not Exist", environmentVariable))
+	boolean, err := strconv.ParseBool(strap)
+	if err != nil {
+		return false, false
 	}
 
-	// Find the environment to use.
-	var useEnv *xmlEnv
-	for _, env := range xmlStraps.Environments {
-		if env.Name == environment {
-			useEnv = &env
-			break
-		}
+	return boolean, true
+}
+
+// TryStrapInt returns the value for the specified key as an int, and whether it was
+// found and successfully parsed.
+func TryStrapInt(key string) (int, bool) {
+	strap, found := TryStrap(key)
+	if !found {
+		return 0, false
 	}
 
-	if useEnv == nil {
-		panic(fmt.Errorf("No Environment With Name %s Found", environment))
+	integer, err := strconv.Atoi(strap)
+	if err != nil {
+		return 0, false
 	}
 
-	return useEnv.Straps
+	return integer, true
 }
+
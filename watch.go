@@ -0,0 +1,188 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Event describes a single strap key whose value changed as the result of a reload
+// triggered by Watch.
+type Event struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+var (
+	onChangeMu    sync.Mutex
+	onChangeFuncs = make(map[string][]func(old, new string))
+)
+
+// Watch watches the file straps was last loaded from for modifications, including
+// replacement by renaming another file over it (as editors commonly do). A swap of a
+// symlink elsewhere in the path, such as the ..data link of a Kubernetes ConfigMap
+// mount, produces no event for the file's own name and is not seen. Each time the file
+// changes, it is re-parsed and the in-memory straps
+// are atomically swapped; an Event is sent on the returned channel for every key whose
+// value changed. The channel is closed and watching stops once ctx is done.
+func Watch(ctx context.Context) (<-chan Event, error) {
+	strapsMu.RLock()
+	path := loadedPath
+	environmentVariable := loadedEnvironmentVariable
+	format := loadedFormat
+	strapsMu.RUnlock()
+
+	if path == "" {
+		return nil, fmt.Errorf("Straps Have Not Been Loaded From A File")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Watch the parent directory rather than the file itself: editors often replace
+	// the config file by renaming a new file over it rather than writing in place.
+	// fsnotify reports that as a Remove/Rename against the old file, which a watch on
+	// the file itself would never see followed by a new Write; watching the directory
+	// and filtering by name catches the replacement as a Create for the same name.
+	dir := filepath.Dir(path)
+	name := filepath.Base(path)
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		defer watcher.Close()
+		defer close(events)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case fsEvent, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Base(fsEvent.Name) != name {
+					continue
+				}
+
+				if fsEvent.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+
+				changes, err := reloadStrapsFile(path, environmentVariable, format)
+				if err != nil {
+					continue
+				}
+
+				for _, change := range changes {
+					notifyOnChange(change)
+
+					select {
+					case events <- change:
+					case <-ctx.Done():
+						return
+					}
+				}
+
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// OnChange registers cb to be called, with the old and new value, whenever key's value
+// changes as the result of Watch reloading the straps file.
+func OnChange(key string, cb func(old, new string)) {
+	onChangeMu.Lock()
+	defer onChangeMu.Unlock()
+
+	onChangeFuncs[key] = append(onChangeFuncs[key], cb)
+}
+
+// notifyOnChange invokes every callback registered for event.Key.
+func notifyOnChange(event Event) {
+	onChangeMu.Lock()
+	callbacks := append([]func(old, new string){}, onChangeFuncs[event.Key]...)
+	onChangeMu.Unlock()
+
+	for _, cb := range callbacks {
+		cb(event.OldValue, event.NewValue)
+	}
+}
+
+// reloadStrapsFile re-reads path, atomically swaps the in-memory strap map, and returns
+// the set of keys whose value changed or disappeared.
+func reloadStrapsFile(path, environmentVariable string, format Format) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	strapMap, err := decodeFormat(format, file, environmentVariable)
+	if err != nil {
+		return nil, err
+	}
+
+	strapsMu.Lock()
+	defer strapsMu.Unlock()
+
+	strapSources = make(map[string]string, len(strapMap))
+	for key := range strapMap {
+		strapSources[key] = path
+	}
+
+	// Replaying the overlays (Merge, LoadDotEnv) and process overrides, then
+	// re-expanding, re-establishes the same precedence the original load went
+	// through. Without this, a reload of just the base file would silently drop
+	// every key layered on top of it and revert any expanded ${VAR} value back to
+	// its raw, unexpanded form.
+	applyOverlaysLocked(strapMap)
+	applyProcessOverridesLocked(strapMap)
+
+	if loadedOptions.ExpandEnv {
+		for key, value := range strapMap {
+			strapMap[key] = expandEnv(value, 0)
+		}
+	}
+
+	var changes []Event
+	for key, newValue := range strapMap {
+		if oldValue, found := st.strapMap[key]; !found || oldValue != newValue {
+			changes = append(changes, Event{Key: key, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	for key, oldValue := range st.strapMap {
+		if _, found := strapMap[key]; !found {
+			changes = append(changes, Event{Key: key, OldValue: oldValue, NewValue: ""})
+		}
+	}
+
+	st = straps{strapMap: strapMap}
+
+	return changes, nil
+}
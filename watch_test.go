@@ -0,0 +1,199 @@
+// Copyright 2013 Ardan Studios. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package straps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReloadStrapsFileReportsChanges checks that only the keys whose value actually
+// changed are reported back.
+func TestReloadStrapsFileReportsChanges(t *testing.T) {
+	const envVar = "STRAPS_TEST_RELOAD_CHANGES_ENV"
+	os.Setenv(envVar, "dev")
+	defer os.Unsetenv(envVar)
+
+	path := filepath.Join(t.TempDir(), "straps.xml")
+
+	initial := `<straps><env name="dev"><strap key="A" value="1"/><strap key="B" value="2"/></env></straps>`
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadFormat(path, envVar, FormatXML); err != nil {
+		t.Fatalf("LoadFormat failed: %v", err)
+	}
+
+	updated := `<straps><env name="dev"><strap key="A" value="1"/><strap key="B" value="3"/></env></straps>`
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := reloadStrapsFile(path, envVar, FormatXML)
+	if err != nil {
+		t.Fatalf("reloadStrapsFile failed: %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Key != "B" || changes[0].OldValue != "2" || changes[0].NewValue != "3" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+}
+
+// TestReloadStrapsFilePreservesProcessOverride is a regression test: a STRAP_<KEY>
+// override established via LoadDotEnv/Merge must survive a Watch-triggered reload of
+// the underlying file instead of being silently replaced by the re-parsed value.
+func TestReloadStrapsFilePreservesProcessOverride(t *testing.T) {
+	const envVar = "STRAPS_TEST_RELOAD_OVERRIDE_ENV"
+	os.Setenv(envVar, "dev")
+	defer os.Unsetenv(envVar)
+
+	path := filepath.Join(t.TempDir(), "straps.xml")
+
+	initial := `<straps><env name="dev"><strap key="Foo" value="file-value"/></env></straps>`
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadFormat(path, envVar, FormatXML); err != nil {
+		t.Fatalf("LoadFormat failed: %v", err)
+	}
+
+	os.Setenv("STRAP_Foo", "override")
+	defer os.Unsetenv("STRAP_Foo")
+
+	if err := LoadDotEnv(); err != nil {
+		t.Fatalf("LoadDotEnv failed: %v", err)
+	}
+
+	if got := Strap("Foo"); got != "override" {
+		t.Fatalf("Strap(Foo) = %q before reload, want %q", got, "override")
+	}
+
+	updated := `<straps><env name="dev"><strap key="Foo" value="updated-file-value"/></env></straps>`
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reloadStrapsFile(path, envVar, FormatXML); err != nil {
+		t.Fatalf("reloadStrapsFile failed: %v", err)
+	}
+
+	if got := Strap("Foo"); got != "override" {
+		t.Errorf("Strap(Foo) = %q after reload, want the override %q to survive", got, "override")
+	}
+}
+
+// TestReloadStrapsFilePreservesDotEnvOverlay is a regression test: a key layered in by
+// LoadDotEnv that does not exist in the base file must survive a Watch-triggered
+// reload of that base file instead of being dropped (and reported as deleted).
+func TestReloadStrapsFilePreservesDotEnvOverlay(t *testing.T) {
+	const envVar = "STRAPS_TEST_RELOAD_OVERLAY_ENV"
+	os.Setenv(envVar, "dev")
+	defer os.Unsetenv(envVar)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "straps.xml")
+
+	initial := `<straps><env name="dev"><strap key="Base" value="base-value"/></env></straps>`
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadFormat(path, envVar, FormatXML); err != nil {
+		t.Fatalf("LoadFormat failed: %v", err)
+	}
+
+	dotEnvPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(dotEnvPath, []byte("Overlay=overlay-value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadDotEnv(dotEnvPath); err != nil {
+		t.Fatalf("LoadDotEnv failed: %v", err)
+	}
+
+	if got := Strap("Overlay"); got != "overlay-value" {
+		t.Fatalf("Strap(Overlay) = %q before reload, want %q", got, "overlay-value")
+	}
+
+	updated := `<straps><env name="dev"><strap key="Base" value="updated-base-value"/></env></straps>`
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := reloadStrapsFile(path, envVar, FormatXML)
+	if err != nil {
+		t.Fatalf("reloadStrapsFile failed: %v", err)
+	}
+
+	for _, change := range changes {
+		if change.Key == "Overlay" {
+			t.Errorf("reload reported a spurious change for overlay-only key Overlay: %+v", change)
+		}
+	}
+
+	if got := Strap("Overlay"); got != "overlay-value" {
+		t.Errorf("Strap(Overlay) = %q after reload, want the overlay %q to survive", got, "overlay-value")
+	}
+
+	if got := Strap("Base"); got != "updated-base-value" {
+		t.Errorf("Strap(Base) = %q after reload, want %q", got, "updated-base-value")
+	}
+}
+
+// TestReloadStrapsFilePreservesExpansion is a regression test: LoadWithOptions'
+// ExpandEnv must keep being applied after a Watch-triggered reload of the base file,
+// not just on the initial load.
+func TestReloadStrapsFilePreservesExpansion(t *testing.T) {
+	const envVar = "STRAPS_TEST_RELOAD_EXPAND_ENV"
+	os.Setenv(envVar, "dev")
+	defer os.Unsetenv(envVar)
+
+	os.Setenv("STRAPS_TEST_RELOAD_EXPAND_HOST", "db.internal")
+	defer os.Unsetenv("STRAPS_TEST_RELOAD_EXPAND_HOST")
+
+	path := filepath.Join(t.TempDir(), "straps.xml")
+
+	initial := `<straps><env name="dev"><strap key="DbHost" value="${STRAPS_TEST_RELOAD_EXPAND_HOST}"/></env></straps>`
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadFrom(file, path, envVar, FormatXML); err != nil {
+		file.Close()
+		t.Fatalf("loadFrom failed: %v", err)
+	}
+	file.Close()
+
+	strapsMu.Lock()
+	loadedOptions = Options{ExpandEnv: true}
+	for key, value := range st.strapMap {
+		st.strapMap[key] = expandEnv(value, 0)
+	}
+	strapsMu.Unlock()
+
+	if got := Strap("DbHost"); got != "db.internal" {
+		t.Fatalf("Strap(DbHost) = %q before reload, want %q", got, "db.internal")
+	}
+
+	updated := `<straps><env name="dev"><strap key="DbHost" value="${STRAPS_TEST_RELOAD_EXPAND_HOST}"/><strap key="Other" value="1"/></env></straps>`
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reloadStrapsFile(path, envVar, FormatXML); err != nil {
+		t.Fatalf("reloadStrapsFile failed: %v", err)
+	}
+
+	if got := Strap("DbHost"); got != "db.internal" {
+		t.Errorf("Strap(DbHost) = %q after reload, want the expanded %q to survive", got, "db.internal")
+	}
+}